@@ -0,0 +1,152 @@
+// Package areaio serializes whole Areas - including their Rooms and NPC
+// spawns - to a stable, versioned JSON schema for offline authoring, backup,
+// and world sharing. JSON is the canonical on-disk and wire format; YAML is
+// accepted on input by round-tripping it through JSON before unmarshalling,
+// so builders can hand-author .yaml area files without the format itself
+// forking in two.
+package areaio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"kmud/database"
+	"kmud/model"
+
+	"github.com/ghodss/yaml"
+)
+
+// CurrentSchemaVersion is written into every Export and checked by Import so
+// that old dumps can be upgraded via migrate() before they're unmarshalled
+// into the current model structs.
+const CurrentSchemaVersion = 1
+
+// RoomExport is the nested, portable form of a database.Room.
+type RoomExport struct {
+	Location    database.Coordinate `json:"location"`
+	Title       string              `json:"title"`
+	Description string              `json:"description"`
+	Exits       []string            `json:"exits"`
+	NpcSpawns   []NpcSpawnExport    `json:"npcSpawns,omitempty"`
+}
+
+// NpcSpawnExport describes an NPC that should exist in a room when the area
+// is imported.
+type NpcSpawnExport struct {
+	Name string `json:"name"`
+}
+
+// AreaExport is the root object written to disk. SchemaVersion lets future
+// format changes be detected and migrated on load instead of silently
+// misparsing.
+type AreaExport struct {
+	SchemaVersion int          `json:"schema_version"`
+	Name          string       `json:"name"`
+	Rooms         []RoomExport `json:"rooms"`
+}
+
+// Export renders area (and every room/NPC spawn in its zone) to the
+// canonical JSON schema.
+func Export(area *database.Area) ([]byte, error) {
+	export := AreaExport{
+		SchemaVersion: CurrentSchemaVersion,
+		Name:          area.GetName(),
+	}
+
+	zone := model.M.GetZone(area.GetZoneId())
+	for _, room := range model.M.GetRoomsInZone(zone) {
+		roomExport := RoomExport{
+			Location:    room.GetLocation(),
+			Title:       room.GetTitle(),
+			Description: room.GetDescription(),
+		}
+
+		for _, dir := range database.AllDirections() {
+			if room.HasExit(dir) {
+				roomExport.Exits = append(roomExport.Exits, dir.String())
+			}
+		}
+
+		for _, npc := range model.M.NpcsIn(room) {
+			roomExport.NpcSpawns = append(roomExport.NpcSpawns, NpcSpawnExport{Name: npc.GetName()})
+		}
+
+		export.Rooms = append(export.Rooms, roomExport)
+	}
+
+	return json.MarshalIndent(export, "", "  ")
+}
+
+// Import parses data - JSON or YAML, detected automatically - into a new
+// database.Area (with its rooms and NPC spawns created alongside it).
+func Import(data []byte) (*database.Area, error) {
+	jsonData, err := toJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(jsonData, &raw); err != nil {
+		return nil, err
+	}
+
+	jsonData, err = migrate(raw, jsonData)
+	if err != nil {
+		return nil, err
+	}
+
+	var export AreaExport
+	if err := json.Unmarshal(jsonData, &export); err != nil {
+		return nil, err
+	}
+
+	zone := model.M.CreateZone(export.Name)
+	area := database.NewArea(export.Name, zone.GetId())
+
+	for _, roomExport := range export.Rooms {
+		room := model.M.CreateRoomAt(zone, roomExport.Location)
+		room.SetTitle(roomExport.Title)
+		room.SetDescription(roomExport.Description)
+
+		for _, exit := range roomExport.Exits {
+			direction := database.StringToDirection(exit)
+			if direction != database.DirectionNone {
+				room.SetExitEnabled(direction, true)
+			}
+		}
+
+		for _, spawn := range roomExport.NpcSpawns {
+			model.M.CreateNpc(spawn.Name, room)
+		}
+	}
+
+	return area, nil
+}
+
+// toJSON detects whether data is already JSON (it starts, ignoring
+// whitespace, with '{' or '[') and if not, round-trips it through YAML.
+func toJSON(data []byte) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return data, nil
+	}
+
+	return yaml.YAMLToJSON(data)
+}
+
+// migrate upgrades an older schema_version payload to CurrentSchemaVersion.
+// There's nothing to migrate yet since this is the format's first version,
+// but the hook exists so a future bump doesn't need to change Import's
+// signature.
+func migrate(raw map[string]interface{}, jsonData []byte) ([]byte, error) {
+	version, _ := raw["schema_version"].(float64)
+
+	switch int(version) {
+	case CurrentSchemaVersion:
+		return jsonData, nil
+	case 0:
+		return nil, fmt.Errorf("areaio: missing schema_version")
+	default:
+		return nil, fmt.Errorf("areaio: unsupported schema_version %v", int(version))
+	}
+}
@@ -1,19 +1,19 @@
 package database
 
-import (
-	"gopkg.in/mgo.v2/bson"
-)
-
 type Area struct {
 	DbObject `bson:",inline"`
 
-	Name   string
-	ZoneId bson.ObjectId
+	Name   string   `json:"name"`
+	ZoneId ObjectId `json:"zoneId"`
 }
 
 type Areas []*Area
 
-func NewArea(name string, zone bson.ObjectId) *Area {
+func init() {
+	RegisterType(AreaType, func() Object { return &Area{} })
+}
+
+func NewArea(name string, zone ObjectId) *Area {
 	var area Area
 
 	area.ZoneId = zone
@@ -46,7 +46,7 @@ func (self *Area) SetName(name string) {
 	}
 }
 
-func (self *Area) GetZoneId() bson.ObjectId {
+func (self *Area) GetZoneId() ObjectId {
 	self.ReadLock()
 	defer self.ReadUnlock()
 
@@ -0,0 +1,57 @@
+package database
+
+// DialogueChoice is one option a player can pick in response to a
+// DialogueNode's line. NextId is the node the conversation continues at, or
+// "" to end the conversation.
+type DialogueChoice struct {
+	Text   string `json:"text"`
+	NextId string `json:"nextId"`
+}
+
+// DialogueNode is a single beat of a branching NPC conversation: a line the
+// NPC says, and the choices a player has in response. SetFlag and GiveItem
+// are optional side effects applied when the node is reached.
+type DialogueNode struct {
+	Id       string           `json:"id"`
+	NpcLine  string           `json:"npcLine"`
+	Choices  []DialogueChoice `json:"choices,omitempty"`
+	SetFlag  string           `json:"setFlag,omitempty"`
+	GiveItem string           `json:"giveItem,omitempty"`
+}
+
+// DialogueTree is the full conversation graph owned by an NPC, replacing
+// the single static `conversation` string. RootId is where `talk` starts.
+type DialogueTree struct {
+	RootId string                   `json:"rootId"`
+	Nodes  map[string]*DialogueNode `json:"nodes"`
+}
+
+// NewDialogueTree returns an empty tree with a single root node.
+func NewDialogueTree() *DialogueTree {
+	root := &DialogueNode{Id: "root"}
+	return &DialogueTree{
+		RootId: root.Id,
+		Nodes:  map[string]*DialogueNode{root.Id: root},
+	}
+}
+
+// Root returns the tree's starting node, or nil if RootId doesn't resolve
+// (which shouldn't happen outside of a corrupt save).
+func (self *DialogueTree) Root() *DialogueNode {
+	return self.Nodes[self.RootId]
+}
+
+// AddNode creates and registers a new, empty node with the given id,
+// overwriting any existing node with that id.
+func (self *DialogueTree) AddNode(id string) *DialogueNode {
+	node := &DialogueNode{Id: id}
+	self.Nodes[id] = node
+	return node
+}
+
+// DeleteNode removes a node from the tree. It does not repair dangling
+// choices that pointed at it, and the tree editor deletes without checking
+// for them; a walk that follows such a choice ends the conversation.
+func (self *DialogueTree) DeleteNode(id string) {
+	delete(self.Nodes, id)
+}
@@ -0,0 +1,269 @@
+// Package fsdb is a filesystem-backed database.Backend for single-binary
+// deployments that don't want a Mongo dependency. Objects are stored one
+// file per object, grouped into one directory per database.ObjectType, and
+// writes are made atomic via a temp-file-plus-rename so a crash mid-write
+// never leaves a half-written object on disk.
+package fsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"kmud/database"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pborman/uuid"
+)
+
+// FSDB is a database.Backend that keeps every object as a JSON file under
+// root/<ObjectType>/<id>.json, plus an in-memory index so List doesn't have
+// to re-walk the directory tree on every call.
+type FSDB struct {
+	root string
+
+	mu    sync.Mutex
+	index map[database.ObjectType]map[database.ObjectId]bool
+	subs  map[database.ObjectType][]chan database.Event
+}
+
+// New opens (and, if necessary, creates) a filesystem backend rooted at dir.
+func New(dir string) (*FSDB, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	db := &FSDB{
+		root:  dir,
+		index: make(map[database.ObjectType]map[database.ObjectId]bool),
+		subs:  make(map[database.ObjectType][]chan database.Event),
+	}
+
+	if err := db.rebuildIndex(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func (self *FSDB) rebuildIndex() error {
+	entries, err := ioutil.ReadDir(self.root)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		t := database.ObjectType(entry.Name())
+		files, err := ioutil.ReadDir(self.typeDir(t))
+		if err != nil {
+			return err
+		}
+
+		ids := make(map[database.ObjectId]bool, len(files))
+		for _, f := range files {
+			id := database.ObjectId(trimExt(f.Name()))
+			ids[id] = true
+		}
+		self.index[t] = ids
+	}
+
+	return nil
+}
+
+func trimExt(name string) string {
+	return name[:len(name)-len(filepath.Ext(name))]
+}
+
+func (self *FSDB) typeDir(t database.ObjectType) string {
+	return filepath.Join(self.root, fmt.Sprintf("%v", t))
+}
+
+func (self *FSDB) objectPath(t database.ObjectType, id database.ObjectId) string {
+	return filepath.Join(self.typeDir(t), id.String()+".json")
+}
+
+func (self *FSDB) NewId(t database.ObjectType) database.ObjectId {
+	return database.ObjectId(uuid.New())
+}
+
+func (self *FSDB) Get(t database.ObjectType, id database.ObjectId) (database.Object, error) {
+	obj := database.NewBlank(t)
+	if obj == nil {
+		return nil, fmt.Errorf("fsdb: no type registered for %v", t)
+	}
+
+	data, err := ioutil.ReadFile(self.objectPath(t, id))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, obj); err != nil {
+		return nil, err
+	}
+	obj.SetId(id)
+
+	return obj, nil
+}
+
+func (self *FSDB) Put(obj database.Object) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.put(obj)
+}
+
+// put performs the actual write. Callers must hold self.mu.
+func (self *FSDB) put(obj database.Object) error {
+	t := obj.GetType()
+	id := obj.GetId()
+	if !id.Valid() {
+		id = self.NewId(t)
+	}
+
+	if err := os.MkdirAll(self.typeDir(t), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := self.objectPath(t, id)
+
+	tmp, err := ioutil.TempFile(self.typeDir(t), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	_, existed := self.index[t][id]
+	if self.index[t] == nil {
+		self.index[t] = make(map[database.ObjectId]bool)
+	}
+	self.index[t][id] = true
+
+	evtType := database.ObjectCreated
+	if existed {
+		evtType = database.ObjectUpdated
+	}
+	self.publish(t, database.Event{Type: evtType, Id: id, Object: obj})
+
+	return nil
+}
+
+func (self *FSDB) Delete(t database.ObjectType, id database.ObjectId) error {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if err := os.Remove(self.objectPath(t, id)); err != nil {
+		return err
+	}
+
+	delete(self.index[t], id)
+	self.publish(t, database.Event{Type: database.ObjectDeleted, Id: id})
+
+	return nil
+}
+
+func (self *FSDB) List(t database.ObjectType) ([]database.Object, error) {
+	self.mu.Lock()
+	ids := make([]database.ObjectId, 0, len(self.index[t]))
+	for id := range self.index[t] {
+		ids = append(ids, id)
+	}
+	self.mu.Unlock()
+
+	objects := make([]database.Object, 0, len(ids))
+	for _, id := range ids {
+		obj, err := self.Get(t, id)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+func (self *FSDB) Watch(t database.ObjectType) <-chan database.Event {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	ch := make(chan database.Event, 16)
+	self.subs[t] = append(self.subs[t], ch)
+	return ch
+}
+
+// publish fans an event out to every Watch subscriber for t. Callers must
+// hold self.mu.
+func (self *FSDB) publish(t database.ObjectType, evt database.Event) {
+	for _, ch := range self.subs[t] {
+		select {
+		case ch <- evt:
+		default:
+			// A slow watcher shouldn't stall writes; it just misses this event.
+		}
+	}
+}
+
+func (self *FSDB) NewBatch() database.Batch {
+	return &batch{fsdb: self}
+}
+
+type batchOp struct {
+	put    database.Object
+	delete bool
+	t      database.ObjectType
+	id     database.ObjectId
+}
+
+type batch struct {
+	fsdb *FSDB
+	ops  []batchOp
+}
+
+func (b *batch) Put(obj database.Object) {
+	b.ops = append(b.ops, batchOp{put: obj})
+}
+
+func (b *batch) Delete(t database.ObjectType, id database.ObjectId) {
+	b.ops = append(b.ops, batchOp{delete: true, t: t, id: id})
+}
+
+func (b *batch) Commit() error {
+	b.fsdb.mu.Lock()
+	defer b.fsdb.mu.Unlock()
+
+	for _, op := range b.ops {
+		if op.delete {
+			if err := os.Remove(b.fsdb.objectPath(op.t, op.id)); err != nil {
+				return err
+			}
+			delete(b.fsdb.index[op.t], op.id)
+			b.fsdb.publish(op.t, database.Event{Type: database.ObjectDeleted, Id: op.id})
+		} else {
+			if err := b.fsdb.put(op.put); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
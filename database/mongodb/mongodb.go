@@ -0,0 +1,124 @@
+// Package mongodb is the original kmud storage backend, now implemented
+// behind database.Backend instead of being wired directly into the model
+// types.
+package mongodb
+
+import (
+	"fmt"
+	"kmud/database"
+
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Backend stores objects in MongoDB, one collection per database.ObjectType.
+type Backend struct {
+	session *mgo.Session
+	db      *mgo.Database
+}
+
+// New connects to the given Mongo URL and returns a ready-to-use Backend.
+func New(url, dbName string) (*Backend, error) {
+	session, err := mgo.Dial(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Backend{
+		session: session,
+		db:      session.DB(dbName),
+	}, nil
+}
+
+func collectionName(t database.ObjectType) string {
+	return fmt.Sprintf("%v", t)
+}
+
+func (self *Backend) collection(t database.ObjectType) *mgo.Collection {
+	return self.db.C(collectionName(t))
+}
+
+func (self *Backend) NewId(t database.ObjectType) database.ObjectId {
+	return database.ObjectId(bson.NewObjectId().Hex())
+}
+
+func (self *Backend) Get(t database.ObjectType, id database.ObjectId) (database.Object, error) {
+	obj := database.NewBlank(t)
+	if obj == nil {
+		return nil, fmt.Errorf("mongodb: no type registered for %v", t)
+	}
+
+	err := self.collection(t).FindId(bson.ObjectIdHex(id.String())).One(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+func (self *Backend) Put(obj database.Object) error {
+	id := obj.GetId()
+	if !id.Valid() {
+		id = self.NewId(obj.GetType())
+	}
+
+	_, err := self.collection(obj.GetType()).UpsertId(bson.ObjectIdHex(id.String()), obj)
+	return err
+}
+
+func (self *Backend) Delete(t database.ObjectType, id database.ObjectId) error {
+	return self.collection(t).RemoveId(bson.ObjectIdHex(id.String()))
+}
+
+func (self *Backend) List(t database.ObjectType) ([]database.Object, error) {
+	var raw []bson.Raw
+	if err := self.collection(t).Find(nil).All(&raw); err != nil {
+		return nil, err
+	}
+
+	objects := make([]database.Object, 0, len(raw))
+	for _, r := range raw {
+		obj := database.NewBlank(t)
+		if obj == nil {
+			return nil, fmt.Errorf("mongodb: no type registered for %v", t)
+		}
+		if err := r.Unmarshal(obj); err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+func (self *Backend) Watch(t database.ObjectType) <-chan database.Event {
+	ch := make(chan database.Event)
+	close(ch)
+	return ch
+}
+
+func (self *Backend) NewBatch() database.Batch {
+	return &batch{backend: self}
+}
+
+type batch struct {
+	backend *Backend
+	ops     []func() error
+}
+
+func (b *batch) Put(obj database.Object) {
+	b.ops = append(b.ops, func() error { return b.backend.Put(obj) })
+}
+
+func (b *batch) Delete(t database.ObjectType, id database.ObjectId) {
+	b.ops = append(b.ops, func() error { return b.backend.Delete(t, id) })
+}
+
+func (b *batch) Commit() error {
+	for _, op := range b.ops {
+		if err := op(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
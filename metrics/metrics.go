@@ -0,0 +1,278 @@
+// Package metrics emits gameplay and system metrics (player count,
+// commands/sec, DB write latency, room event counts, per-command timings)
+// in the InfluxDB/Telegraf line protocol, so operators can scrape kmud with
+// whatever agent they already run.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Tags is an unordered set of key/value pairs attached to a metric, e.g.
+// {"plugin": "kmud", "area": "Blackwood"}.
+type Tags map[string]string
+
+func (t Tags) render(buf *bytes.Buffer) {
+	if len(t) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		buf.WriteByte(',')
+		buf.WriteString(k)
+		buf.WriteByte('=')
+		buf.WriteString(t[k])
+	}
+}
+
+// Counter is a monotonically increasing value, e.g. "rooms visited". Add is
+// safe to call from any number of goroutines concurrently: it's a single
+// atomic add per call, never a mutex.
+type Counter struct {
+	value int64
+}
+
+func (c *Counter) Add(delta int64) {
+	atomic.AddInt64(&c.value, delta)
+}
+
+func (c *Counter) Value() int64 {
+	return atomic.LoadInt64(&c.value)
+}
+
+// Gauge is a point-in-time value, e.g. "players online".
+type Gauge struct {
+	bits int64
+}
+
+func (g *Gauge) Set(v float64) {
+	atomic.StoreInt64(&g.bits, int64(math.Float64bits(v)))
+}
+
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(uint64(atomic.LoadInt64(&g.bits)))
+}
+
+// Timer accumulates duration samples into a small fixed-function histogram:
+// count, sum, min, and max. That's enough to compute an average server-side
+// without kmud having to carry a full quantile sketch.
+type Timer struct {
+	count int64
+	sumNs int64
+	minNs int64
+	maxNs int64
+}
+
+func (t *Timer) Observe(d time.Duration) {
+	ns := int64(d)
+
+	atomic.AddInt64(&t.count, 1)
+	atomic.AddInt64(&t.sumNs, ns)
+
+	for {
+		min := atomic.LoadInt64(&t.minNs)
+		if min != 0 && min <= ns {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&t.minNs, min, ns) {
+			break
+		}
+	}
+
+	for {
+		max := atomic.LoadInt64(&t.maxNs)
+		if max >= ns {
+			break
+		}
+		if atomic.CompareAndSwapInt64(&t.maxNs, max, ns) {
+			break
+		}
+	}
+}
+
+// Time is a convenience for the common "time this call" pattern:
+//
+//	defer registry.Timer("db.write", nil).Time()()
+func (t *Timer) Time() func() {
+	start := time.Now()
+	return func() {
+		t.Observe(time.Since(start))
+	}
+}
+
+type metricKey struct {
+	measurement string
+	tagKey      string
+}
+
+// Registry owns every Counter, Gauge, and Timer registered under it and
+// knows how to render them all as line-protocol text.
+type Registry struct {
+	mu       sync.Mutex
+	tags     map[metricKey]Tags
+	counters map[metricKey]*Counter
+	gauges   map[metricKey]*Gauge
+	timers   map[metricKey]*Timer
+}
+
+// Default is the process-wide Registry that package-level instrumentation
+// (database writes, input timing, etc) reports into. Most callers never
+// need their own Registry.
+var Default = NewRegistry()
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		tags:     make(map[metricKey]Tags),
+		counters: make(map[metricKey]*Counter),
+		gauges:   make(map[metricKey]*Gauge),
+		timers:   make(map[metricKey]*Timer),
+	}
+}
+
+func tagKey(tags Tags) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&buf, "%s=%s,", k, tags[k])
+	}
+	return buf.String()
+}
+
+// Counter returns the named/tagged Counter, creating it on first use.
+func (r *Registry) Counter(measurement string, tags Tags) *Counter {
+	key := metricKey{measurement, tagKey(tags)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.counters[key]
+	if !ok {
+		c = &Counter{}
+		r.counters[key] = c
+		r.tags[key] = tags
+	}
+	return c
+}
+
+// Gauge returns the named/tagged Gauge, creating it on first use.
+func (r *Registry) Gauge(measurement string, tags Tags) *Gauge {
+	key := metricKey{measurement, tagKey(tags)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g, ok := r.gauges[key]
+	if !ok {
+		g = &Gauge{}
+		r.gauges[key] = g
+		r.tags[key] = tags
+	}
+	return g
+}
+
+// Timer returns the named/tagged Timer, creating it on first use.
+func (r *Registry) Timer(measurement string, tags Tags) *Timer {
+	key := metricKey{measurement, tagKey(tags)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.timers[key]
+	if !ok {
+		t = &Timer{}
+		r.timers[key] = t
+		r.tags[key] = tags
+	}
+	return t
+}
+
+// Render produces one line-protocol line per metric:
+//
+//	measurement,tag=val field=val ts
+func (r *Registry) Render(now time.Time) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var buf bytes.Buffer
+	ts := now.UnixNano()
+
+	for key, c := range r.counters {
+		buf.WriteString(key.measurement)
+		r.tags[key].render(&buf)
+		fmt.Fprintf(&buf, " value=%di %d\n", c.Value(), ts)
+	}
+
+	for key, g := range r.gauges {
+		buf.WriteString(key.measurement)
+		r.tags[key].render(&buf)
+		fmt.Fprintf(&buf, " value=%g %d\n", g.Value(), ts)
+	}
+
+	for key, t := range r.timers {
+		buf.WriteString(key.measurement)
+		r.tags[key].render(&buf)
+		fmt.Fprintf(&buf, " count=%di,sum_ns=%di,min_ns=%di,max_ns=%di %d\n",
+			atomic.LoadInt64(&t.count), atomic.LoadInt64(&t.sumNs),
+			atomic.LoadInt64(&t.minNs), atomic.LoadInt64(&t.maxNs), ts)
+	}
+
+	return buf.Bytes()
+}
+
+// Handler returns an http.Handler that serves the current Render output in
+// response to any GET request, for a Prometheus/Telegraf-style scrape.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(r.Render(time.Now()))
+	})
+}
+
+// StartUDPPusher renders and sends the registry to addr (a Telegraf/InfluxDB
+// UDP listener) every interval, until the returned func is called to stop
+// it.
+func (r *Registry) StartUDPPusher(addr string, interval time.Duration) (stop func(), err error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		defer conn.Close()
+
+		for {
+			select {
+			case <-ticker.C:
+				conn.Write(r.Render(time.Now()))
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }, nil
+}
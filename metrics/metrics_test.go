@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// parsedLine mirrors what a downstream telegraf-style consumer extracts from
+// a single line-protocol line. It exists only to verify Render's output in
+// these tests.
+type parsedLine struct {
+	measurement string
+	tags        map[string]string
+	fields      map[string]string
+	timestamp   int64
+}
+
+// parseLine is a deliberately small line-protocol parser - just enough to
+// validate what Registry.Render emits. It is not meant to handle the full
+// line-protocol grammar (escaped commas/spaces, string fields, etc).
+func parseLine(line string) (parsedLine, error) {
+	parts := strings.Split(line, " ")
+	if len(parts) != 3 {
+		return parsedLine{}, fmt.Errorf("expected 3 space-separated sections, got %d: %q", len(parts), line)
+	}
+
+	ident := strings.Split(parts[0], ",")
+	fieldsPart, tsPart := parts[1], parts[2]
+
+	tags := make(map[string]string)
+	for _, kv := range ident[1:] {
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) != 2 {
+			return parsedLine{}, fmt.Errorf("malformed tag %q", kv)
+		}
+		tags[pair[0]] = pair[1]
+	}
+
+	fields := make(map[string]string)
+	for _, kv := range strings.Split(fieldsPart, ",") {
+		pair := strings.SplitN(kv, "=", 2)
+		if len(pair) != 2 {
+			return parsedLine{}, fmt.Errorf("malformed field %q", kv)
+		}
+		fields[pair[0]] = pair[1]
+	}
+
+	ts, err := strconv.ParseInt(tsPart, 10, 64)
+	if err != nil {
+		return parsedLine{}, fmt.Errorf("malformed timestamp %q: %v", tsPart, err)
+	}
+
+	return parsedLine{measurement: ident[0], tags: tags, fields: fields, timestamp: ts}, nil
+}
+
+func Test_RenderCounter(t *testing.T) {
+	r := NewRegistry()
+	r.Counter("commands", Tags{"plugin": "kmud"}).Add(3)
+	r.Counter("commands", Tags{"plugin": "kmud"}).Add(4)
+
+	lines := strings.TrimSpace(string(r.Render(time.Now())))
+	parsed, err := parseLine(lines)
+	if err != nil {
+		t.Fatalf("parseLine(%q): %v", lines, err)
+	}
+
+	if parsed.measurement != "commands" {
+		t.Errorf("measurement == %q, want %q", parsed.measurement, "commands")
+	}
+	if parsed.tags["plugin"] != "kmud" {
+		t.Errorf("tags[plugin] == %q, want %q", parsed.tags["plugin"], "kmud")
+	}
+	if parsed.fields["value"] != "7i" {
+		t.Errorf("fields[value] == %q, want %q", parsed.fields["value"], "7i")
+	}
+}
+
+func Test_RenderGauge(t *testing.T) {
+	r := NewRegistry()
+	r.Gauge("players_online", nil).Set(12)
+
+	lines := strings.TrimSpace(string(r.Render(time.Now())))
+	parsed, err := parseLine(lines)
+	if err != nil {
+		t.Fatalf("parseLine(%q): %v", lines, err)
+	}
+
+	if parsed.fields["value"] != "12" {
+		t.Errorf("fields[value] == %q, want %q", parsed.fields["value"], "12")
+	}
+}
+
+func Test_RenderTimer(t *testing.T) {
+	r := NewRegistry()
+	timer := r.Timer("db.write", Tags{"area": "Blackwood"})
+	timer.Observe(10 * time.Millisecond)
+	timer.Observe(20 * time.Millisecond)
+
+	lines := strings.TrimSpace(string(r.Render(time.Now())))
+	parsed, err := parseLine(lines)
+	if err != nil {
+		t.Fatalf("parseLine(%q): %v", lines, err)
+	}
+
+	if parsed.fields["count"] != "2i" {
+		t.Errorf("fields[count] == %q, want %q", parsed.fields["count"], "2i")
+	}
+	if parsed.tags["area"] != "Blackwood" {
+		t.Errorf("tags[area] == %q, want %q", parsed.tags["area"], "Blackwood")
+	}
+}
+
+func Test_TimerFunc(t *testing.T) {
+	r := NewRegistry()
+	timer := r.Timer("handler", nil)
+
+	done := timer.Time()
+	time.Sleep(time.Millisecond)
+	done()
+
+	if timer.count != 1 {
+		t.Errorf("timer.count == %v, want 1", timer.count)
+	}
+}
+
+// BenchmarkCounterAdd demonstrates the fast path is lock-free: every
+// goroutine hits a single atomic add, with no contention on a shared mutex.
+func BenchmarkCounterAdd(b *testing.B) {
+	c := &Counter{}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			c.Add(1)
+		}
+	})
+}
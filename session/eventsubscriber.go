@@ -0,0 +1,161 @@
+package session
+
+import (
+	"kmud/model"
+	"sync"
+)
+
+// eventSubscriberCapacity bounds the display-event ring buffer each
+// Session hands to model.Register. It's small on purpose: the writer
+// goroutine drains it continuously, so depth only matters for the brief
+// window while a client is slow or sitting at a prompt.
+const eventSubscriberCapacity = 32
+
+// eventSubscriber is the Subscriber each Session hands to model.Register in
+// place of the old single eventChannel. Its TryDeliver is called directly from
+// whichever goroutine is broadcasting (BroadcastMessage/Say/Tell), so it
+// must never block: room-state events (which mutate session.room, and so
+// have to be applied on the session's own goroutine) are handed off on a
+// small side channel, while everything else lands in a bounded ring
+// buffer that the session's dedicated writer goroutine drains on its own
+// schedule. A stuck client stalls neither the broadcaster nor any other
+// session.
+type eventSubscriber struct {
+	mu      sync.Mutex
+	ring    []model.Event
+	head    int
+	count   int
+	dropped int
+	closed  bool
+
+	wake  chan struct{}
+	rooms chan model.Event
+}
+
+func newEventSubscriber() *eventSubscriber {
+	return &eventSubscriber{
+		ring:  make([]model.Event, eventSubscriberCapacity),
+		wake:  make(chan struct{}, 1),
+		rooms: make(chan model.Event, 8),
+	}
+}
+
+// isCriticalEvent reports whether an event must never be silently
+// dropped under backpressure - tells and other addressed messages, as
+// opposed to high-frequency ambient noise like movement and emotes.
+func isCriticalEvent(event model.Event) bool {
+	switch event.Type() {
+	case model.MovementEventType, model.EmoteEventType:
+		return false
+	default:
+		return true
+	}
+}
+
+// TryDeliver is the Subscriber interface method the broadcast path calls.
+// It never blocks: room updates go on the rooms channel (itself buffered,
+// so a momentary burst doesn't force a drop), everything else goes in the
+// ring, evicting the oldest non-critical entry to make room if full.
+func (s *eventSubscriber) TryDeliver(event model.Event) bool {
+	if event.Type() == model.RoomUpdateEventType {
+		select {
+		case s.rooms <- event:
+			return true
+		default:
+			return false
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.count == len(s.ring) && !s.evictOldestNonCriticalLocked() {
+		if !isCriticalEvent(event) {
+			s.dropped++
+			return false
+		}
+
+		// Every slot is critical; the ring is bounded, so the oldest
+		// has to go even though it's critical too.
+		s.head = (s.head + 1) % len(s.ring)
+		s.count--
+		s.dropped++
+	}
+
+	s.ring[(s.head+s.count)%len(s.ring)] = event
+	s.count++
+
+	s.wakeLocked()
+
+	return true
+}
+
+// wakeLocked signals next() that there's something to read. Caller must
+// hold s.mu. wake is never closed - just signaled under the same lock
+// close() sets the closed flag under - so a TryDeliver racing a
+// disconnect can never send on a closed channel.
+func (s *eventSubscriber) wakeLocked() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// evictOldestNonCriticalLocked removes the oldest non-critical event in
+// the ring, if any, shifting later entries back to fill the hole. Caller
+// must hold s.mu.
+func (s *eventSubscriber) evictOldestNonCriticalLocked() bool {
+	for i := 0; i < s.count; i++ {
+		idx := (s.head + i) % len(s.ring)
+		if isCriticalEvent(s.ring[idx]) {
+			continue
+		}
+
+		for j := i; j < s.count-1; j++ {
+			from := (s.head + j + 1) % len(s.ring)
+			to := (s.head + j) % len(s.ring)
+			s.ring[to] = s.ring[from]
+		}
+
+		s.count--
+		s.dropped++
+		return true
+	}
+	return false
+}
+
+// next blocks until a display event is available (or the subscriber is
+// closed), returning it along with how many events were dropped since the
+// last call - almost always 0, and >0 exactly once right after a
+// backpressure episode, so the writer can emit a single coalesced
+// "[N events dropped]" line instead of one per drop.
+func (s *eventSubscriber) next() (event model.Event, dropped int, ok bool) {
+	for {
+		s.mu.Lock()
+		if s.count > 0 {
+			event = s.ring[s.head]
+			s.ring[s.head] = nil
+			s.head = (s.head + 1) % len(s.ring)
+			s.count--
+			dropped = s.dropped
+			s.dropped = 0
+			s.mu.Unlock()
+			return event, dropped, true
+		}
+		if s.closed {
+			s.mu.Unlock()
+			return nil, 0, false
+		}
+		s.mu.Unlock()
+
+		<-s.wake
+	}
+}
+
+// close shuts the subscriber down, waking anything blocked in next().
+func (s *eventSubscriber) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.wakeLocked()
+	s.mu.Unlock()
+}
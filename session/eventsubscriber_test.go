@@ -0,0 +1,113 @@
+package session
+
+import (
+	"kmud/database"
+	"kmud/model"
+	"testing"
+)
+
+// fakeEvent is a minimal model.Event for exercising eventSubscriber without
+// a real model package registry behind it.
+type fakeEvent struct {
+	kind model.EventType
+}
+
+func (e fakeEvent) Type() model.EventType {
+	return e.kind
+}
+
+func (e fakeEvent) ToString(player *database.Character) string {
+	return "event"
+}
+
+func Test_EventSubscriber_DropsOldestNonCriticalWhenFull(t *testing.T) {
+	s := newEventSubscriber()
+
+	for i := 0; i < eventSubscriberCapacity; i++ {
+		if !s.TryDeliver(fakeEvent{kind: model.MovementEventType}) {
+			t.Fatalf("TryDeliver unexpectedly rejected event %d while ring had room", i)
+		}
+	}
+
+	// The ring is now full of non-critical movement events; a tell must
+	// still get in by evicting the oldest one, not get dropped itself.
+	if !s.TryDeliver(fakeEvent{kind: model.TellEventType}) {
+		t.Fatal("TryDeliver rejected a critical event instead of evicting a non-critical one")
+	}
+
+	var sawTell bool
+	for i := 0; i < eventSubscriberCapacity; i++ {
+		event, _, ok := s.next()
+		if !ok {
+			t.Fatalf("next() ran dry after %d events, want %d", i, eventSubscriberCapacity)
+		}
+		if event.Type() == model.TellEventType {
+			sawTell = true
+		}
+	}
+
+	if !sawTell {
+		t.Error("tell event was evicted instead of a non-critical movement event")
+	}
+}
+
+func Test_EventSubscriber_CoalescesDroppedCount(t *testing.T) {
+	s := newEventSubscriber()
+
+	for i := 0; i < eventSubscriberCapacity+5; i++ {
+		s.TryDeliver(fakeEvent{kind: model.MovementEventType})
+	}
+
+	var totalDropped int
+	for i := 0; i < eventSubscriberCapacity; i++ {
+		_, dropped, ok := s.next()
+		if !ok {
+			t.Fatalf("next() ran dry early at %d", i)
+		}
+		totalDropped += dropped
+	}
+
+	if totalDropped != 5 {
+		t.Errorf("totalDropped == %d, want 5", totalDropped)
+	}
+}
+
+// BenchmarkTryDeliver_StuckClient models the scenario the sharded design
+// fixes: nothing is ever draining the subscriber (a client parked at a
+// RawUserInput prompt), so every delivery either fills the ring or evicts
+// from it. It should stay fast and allocation-light regardless - that's
+// what lets model.BroadcastMessage fan out to a stuck session without
+// stalling every other one.
+func BenchmarkTryDeliver_StuckClient(b *testing.B) {
+	s := newEventSubscriber()
+	event := fakeEvent{kind: model.MovementEventType}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.TryDeliver(event)
+	}
+}
+
+// BenchmarkTryDeliver_Concurrent fans deliveries in from many goroutines
+// (standing in for many broadcasters) against a single slow-draining
+// subscriber, demonstrating TryDeliver itself never blocks a caller.
+func BenchmarkTryDeliver_Concurrent(b *testing.B) {
+	s := newEventSubscriber()
+	event := fakeEvent{kind: model.MovementEventType}
+
+	defer s.close()
+	go func() {
+		for {
+			if _, _, ok := s.next(); !ok {
+				return
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.TryDeliver(event)
+		}
+	})
+}
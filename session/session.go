@@ -3,12 +3,16 @@ package session
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
+	"kmud/areaio"
 	"kmud/database"
+	"kmud/metrics"
 	"kmud/model"
 	"kmud/utils"
 	"labix.org/v2/mgo/bson"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,6 +22,24 @@ type Session struct {
 	player *database.Character
 	room   *database.Room
 	zone   *database.Zone
+
+	// xhair is the active aim-mode crosshair, distinct from the player's
+	// actual location (room.GetLocation()). Nil when aim mode isn't
+	// active. See the /aim family of commands.
+	xhair *database.Coordinate
+
+	// outputMu serializes writes to conn: Exec's own goroutine and the
+	// dedicated event-writer goroutine it starts both print to the same
+	// connection, and io.Writer makes no concurrency guarantees.
+	outputMu sync.Mutex
+
+	// inputTimer and writeBytesGauge are resolved once, in NewSession,
+	// rather than looked up by name on every read/write: Registry.Timer
+	// and Registry.Gauge take the registry's mutex to do their
+	// create-on-first-use check, and that's the wrong thing to pay on
+	// every keystroke or every line printed.
+	inputTimer      *metrics.Timer
+	writeBytesGauge *metrics.Gauge
 }
 
 func NewSession(conn io.ReadWriter, user *database.User, player *database.Character) Session {
@@ -27,6 +49,8 @@ func NewSession(conn io.ReadWriter, user *database.User, player *database.Charac
 	session.player = player
 	session.room = model.M.GetRoom(player.GetRoomId())
 	session.zone = model.M.GetZone(session.room.GetZoneId())
+	session.inputTimer = metrics.Default.Timer("session.input", nil)
+	session.writeBytesGauge = metrics.Default.Gauge("session.write_bytes", nil)
 
 	return session
 }
@@ -38,6 +62,88 @@ const (
 	RawUserInput   userInputMode = iota
 )
 
+// directionDelta returns the per-axis step a single move in dir represents,
+// the same math a room's own NextLocation applies, but usable against an
+// arbitrary Coordinate rather than a room's own location - which is what
+// aim mode needs, since the crosshair usually isn't the player's room.
+func directionDelta(dir database.ExitDirection) database.Coordinate {
+	switch dir {
+	case database.DirectionNorth:
+		return database.Coordinate{X: 0, Y: -1, Z: 0}
+	case database.DirectionNorthEast:
+		return database.Coordinate{X: 1, Y: -1, Z: 0}
+	case database.DirectionEast:
+		return database.Coordinate{X: 1, Y: 0, Z: 0}
+	case database.DirectionSouthEast:
+		return database.Coordinate{X: 1, Y: 1, Z: 0}
+	case database.DirectionSouth:
+		return database.Coordinate{X: 0, Y: 1, Z: 0}
+	case database.DirectionSouthWest:
+		return database.Coordinate{X: -1, Y: 1, Z: 0}
+	case database.DirectionWest:
+		return database.Coordinate{X: -1, Y: 0, Z: 0}
+	case database.DirectionNorthWest:
+		return database.Coordinate{X: -1, Y: -1, Z: 0}
+	case database.DirectionUp:
+		return database.Coordinate{X: 0, Y: 0, Z: 1}
+	case database.DirectionDown:
+		return database.Coordinate{X: 0, Y: 0, Z: -1}
+	default:
+		return database.Coordinate{}
+	}
+}
+
+// maxAliasDepth caps how many aliases an alias expansion is allowed to chain
+// through before executeLine gives up, so "/alias loop /loop" can't recurse
+// forever.
+const maxAliasDepth = 8
+
+// substituteAliasArgs replaces $1..$n with the corresponding invocation
+// argument and $* with all of them joined by spaces.
+func substituteAliasArgs(body string, args []string) string {
+	result := body
+
+	for i, arg := range args {
+		result = strings.Replace(result, fmt.Sprintf("$%d", i+1), arg, -1)
+	}
+
+	result = strings.Replace(result, "$*", strings.Join(args, " "), -1)
+
+	return result
+}
+
+// splitAliasSegments breaks an expanded alias body into its individual
+// command lines: a new segment starts at every "/"-prefixed field, and any
+// following non-slash fields are that command's arguments. "/n /n /e" is
+// three segments; "/create $1" (post-substitution "/create Sword") is one.
+func splitAliasSegments(body string) []string {
+	var segments []string
+	var current []string
+
+	for _, field := range strings.Fields(body) {
+		if strings.HasPrefix(field, "/") && len(current) > 0 {
+			segments = append(segments, strings.Join(current, " "))
+			current = nil
+		}
+		current = append(current, field)
+	}
+
+	if len(current) > 0 {
+		segments = append(segments, strings.Join(current, " "))
+	}
+
+	return segments
+}
+
+// minMax returns a and b in ascending order, for turning two arbitrary
+// corners into a normalized (start, end) range.
+func minMax(a, b int) (int, int) {
+	if a > b {
+		return b, a
+	}
+	return a, b
+}
+
 func toggleExitMenu(cm utils.ColorMode, room *database.Room) utils.Menu {
 	onOrOff := func(direction database.ExitDirection) string {
 		text := "Off"
@@ -84,27 +190,102 @@ func specificNpcMenu(npcId bson.ObjectId) utils.Menu {
 	menu := utils.NewMenu(npc.PrettyName())
 	menu.AddAction("r", "[R]ename")
 	menu.AddAction("d", "[D]elete")
-	menu.AddAction("c", "[C]onversation")
+	menu.AddAction("t", "[T]ree")
 	return menu
 }
 
-func (session *Session) Exec() {
-	processEvent := func(event model.Event) string {
-		message := event.ToString(session.player)
+func dialogueEditorMenu(tree *database.DialogueTree) utils.Menu {
+	menu := utils.NewMenu("Dialogue Tree")
+	menu.AddAction("a", "[A]dd node")
+	menu.AddAction("l", "[L]ink choice")
+	menu.AddAction("del", "[Del]ete node")
+	menu.AddAction("root", "Set [Root]")
+	menu.AddAction("test", "[Test]-run")
+
+	for id, node := range tree.Nodes {
+		label := id + ": " + node.NpcLine
+		if id == tree.RootId {
+			label += " (root)"
+		}
+		menu.AddAction(id, label)
+	}
 
-		switch event.Type() {
-		case model.RoomUpdateEventType:
-			roomEvent := event.(model.RoomUpdateEvent)
-			if roomEvent.Room.GetId() == session.room.GetId() {
-				session.room = roomEvent.Room
-			}
+	return menu
+}
+
+// macroRecorder tracks an in-progress /record session: every command line
+// the user enters (via getUserInput, so this covers /n /e chains and
+// edit-mode sub-prompts alike) is appended to lines until /endrecord.
+type macroRecorder struct {
+	active bool
+	name   string
+	lines  []string
+}
+
+func (session *Session) Exec() {
+	recorder := macroRecorder{}
+
+	// applyRoomEvent is the only piece of event handling that still has to
+	// run on the session's own goroutine: it mutates session.room, which
+	// nothing else touches concurrently.
+	applyRoomEvent := func(event model.Event) {
+		roomEvent := event.(model.RoomUpdateEvent)
+		if roomEvent.Room.GetId() == session.room.GetId() {
+			session.room = roomEvent.Room
 		}
+	}
 
-		return message
+	subscriber := newEventSubscriber()
+	model.Register(session.player, subscriber)
+
+	// currentPrompt is read by the writer goroutine below so it can
+	// reprint the right prompt after a display event, without the writer
+	// needing to share getUserInput's call stack.
+	var promptMu sync.Mutex
+	currentPrompt := ""
+	setPrompt := func(prompt string) {
+		promptMu.Lock()
+		currentPrompt = prompt
+		promptMu.Unlock()
 	}
 
-	eventChannel := model.Register(session.player)
-	defer model.Unregister(eventChannel)
+	// The writer goroutine owns all display-event output: it drains the
+	// subscriber's ring buffer independently of whatever the session
+	// goroutine is doing (including sitting idle at a RawUserInput
+	// prompt), so a slow client only ever backs up its own ring buffer
+	// instead of the shared model.BroadcastMessage/Say/Tell fan-out.
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+
+		for {
+			event, dropped, ok := subscriber.next()
+			if !ok {
+				return
+			}
+
+			message := event.ToString(session.player)
+
+			promptMu.Lock()
+			prompt := currentPrompt
+			promptMu.Unlock()
+
+			if dropped > 0 || message != "" {
+				session.clearLine()
+				if dropped > 0 {
+					session.printLine("[%d events dropped]", dropped)
+				}
+				if message != "" {
+					session.printLine(message)
+				}
+				session.printString(prompt)
+			}
+		}
+	}()
+
+	defer func() { <-writerDone }()
+	defer subscriber.close()
+	defer model.Unregister(session.player)
 
 	userInputChannel := make(chan string)
 	promptChannel := make(chan string)
@@ -115,23 +296,22 @@ func (session *Session) Exec() {
 	/**
 	 * Allows us to retrieve user input in a way that doesn't block the
 	 * event loop by using channels and a separate Go routine to grab
-	 * either the next user input or the next event.
+	 * either the next user input or the next room-state update.
 	 */
 	getUserInput := func(inputMode userInputMode, prompt string) string {
+		setPrompt(prompt)
 		inputModeChannel <- inputMode
 		promptChannel <- prompt
 
 		for {
 			select {
 			case input := <-userInputChannel:
-				return input
-			case event := <-eventChannel:
-				message := processEvent(event)
-				if message != "" {
-					session.clearLine()
-					session.printLine(message)
-					session.printString(prompt)
+				if recorder.active && input != "/endrecord" {
+					recorder.lines = append(recorder.lines, input)
 				}
+				return input
+			case event := <-subscriber.rooms:
+				applyRoomEvent(event)
 			case quitMessage := <-panicChannel:
 				panic(quitMessage)
 			}
@@ -156,7 +336,123 @@ func (session *Session) Exec() {
 		return choice, data
 	}
 
-	processCommand := func(command string, args []string) {
+	var processCommand func(command string, args []string)
+
+	// executeLine is the single entry point for running one line of input,
+	// whether it came from the prompt, a macro, or an alias expansion:
+	// it expands the leading token against the user's alias table (up to
+	// maxAliasDepth deep, so an alias can't recurse forever) before falling
+	// through to the normal command/action dispatch.
+	var executeLine func(line string, depth int)
+
+	executeLine = func(line string, depth int) {
+		if !strings.HasPrefix(line, "/") {
+			action, actionArgs := utils.Argify(line)
+
+			// look with no target falls back to the crosshair the same way
+			// /teleport and /destroyroom do, so aiming and looking work
+			// together without retyping coordinates.
+			if (action == "look" || action == "l") && len(actionArgs) == 0 && session.xhair != nil {
+				xhairRoom := model.M.GetRoomByLocation(*session.xhair, session.zone)
+				if xhairRoom == nil {
+					session.printError("No room there")
+				} else {
+					session.printRoomAt(xhairRoom)
+				}
+				return
+			}
+
+			session.handleAction(action, actionArgs)
+			return
+		}
+
+		command, args := utils.Argify(line[1:])
+
+		if depth < maxAliasDepth {
+			if body, ok := session.user.GetAlias(command); ok {
+				delay := time.Duration(200) * time.Millisecond
+
+				for _, segment := range splitAliasSegments(substituteAliasArgs(body, args)) {
+					executeLine(segment, depth+1)
+					time.Sleep(delay)
+				}
+
+				return
+			}
+		}
+
+		processCommand(command, args)
+	}
+
+	// playMacro replays a recorded macro's lines through the same
+	// processCommand/handleAction dispatch live input goes through,
+	// honoring the same throttle the input goroutine applies. The input
+	// goroutine only reads the socket in response to a pending
+	// getUserInput request, so there's no live input to race against
+	// here; playback always runs to completion.
+	playMacro := func(lines []string, count int) {
+		delay := time.Duration(200) * time.Millisecond
+
+		for i := 0; i < count; i++ {
+			for _, line := range lines {
+				executeLine(line, 0)
+
+				time.Sleep(delay)
+			}
+		}
+	}
+
+	// walkDialogueTreeFrom plays out tree starting at nodeId: print the
+	// NPC's line, apply any flag/item side effects, present the player's
+	// choices, and follow whichever one they pick until a node with no
+	// choices ends the conversation. Its only callers are the tree editor's
+	// test-run and node jump, which set dryRun so trying out a tree doesn't
+	// actually flag the builder's own character or hand them items; no
+	// `talk <npc>` action walks a tree.
+	walkDialogueTreeFrom := func(tree *database.DialogueTree, nodeId string, dryRun bool) {
+		for nodeId != "" {
+			node, ok := tree.Nodes[nodeId]
+			if !ok {
+				return
+			}
+
+			session.printLine("%s", node.NpcLine)
+
+			if !dryRun {
+				if node.SetFlag != "" {
+					session.player.SetFlag(node.SetFlag, true)
+				}
+
+				if node.GiveItem != "" {
+					item := model.M.CreateItem(node.GiveItem)
+					session.player.AddItem(item)
+					session.printLine("You received: %s", item.GetName())
+				}
+			}
+
+			if len(node.Choices) == 0 {
+				return
+			}
+
+			for i, choice := range node.Choices {
+				session.printLine("%d) %s", i+1, choice.Text)
+			}
+
+			input := getUserInput(CleanUserInput, "> ")
+			index, err := strconv.Atoi(input)
+			if err != nil || index < 1 || index > len(node.Choices) {
+				return
+			}
+
+			nodeId = node.Choices[index-1].NextId
+		}
+	}
+
+	walkDialogueTree := func(tree *database.DialogueTree, dryRun bool) {
+		walkDialogueTreeFrom(tree, tree.RootId, dryRun)
+	}
+
+	processCommand = func(command string, args []string) {
 		switch command {
 		case "help":
 		case "edit":
@@ -335,12 +631,143 @@ func (session *Session) Exec() {
 							// space to leave room for the exit lines
 							builder.addRoom(room, (x-startX)*2, (y-startY)*2, z-startZ)
 						}
+
+						if session.xhair != nil && loc == *session.xhair {
+							builder.setCrosshair((x-startX)*2, (y-startY)*2, z-startZ)
+						}
 					}
 				}
 			}
 
 			session.printLine(utils.TrimEmptyRows(builder.toString(session.user.GetColorMode())))
 
+		case "batch":
+			batchUsage := func() {
+				session.printError("Usage: /batch here <radius> | /batch <x1> <y1> <z1> <x2> <y2> <z2>")
+			}
+
+			var corner1, corner2 database.Coordinate
+
+			switch {
+			case len(args) == 2 && args[0] == "here":
+				radius, err := strconv.Atoi(args[1])
+				if err != nil || radius < 0 {
+					batchUsage()
+					return
+				}
+
+				here := session.room.GetLocation()
+				corner1 = database.Coordinate{X: here.X - radius, Y: here.Y - radius, Z: here.Z}
+				corner2 = database.Coordinate{X: here.X + radius, Y: here.Y + radius, Z: here.Z}
+
+			case len(args) == 6:
+				coords := make([]int, 6)
+				for i, arg := range args {
+					v, err := strconv.Atoi(arg)
+					if err != nil {
+						batchUsage()
+						return
+					}
+					coords[i] = v
+				}
+				corner1 = database.Coordinate{X: coords[0], Y: coords[1], Z: coords[2]}
+				corner2 = database.Coordinate{X: coords[3], Y: coords[4], Z: coords[5]}
+
+			case len(args) == 0 && session.xhair != nil:
+				corner1 = session.room.GetLocation()
+				corner2 = *session.xhair
+
+			default:
+				batchUsage()
+				return
+			}
+
+			startX, endX := minMax(corner1.X, corner2.X)
+			startY, endY := minMax(corner1.Y, corner2.Y)
+			startZ, endZ := minMax(corner1.Z, corner2.Z)
+
+			var selection []database.Coordinate
+			for z := startZ; z <= endZ; z++ {
+				for y := startY; y <= endY; y++ {
+					for x := startX; x <= endX; x++ {
+						selection = append(selection, database.Coordinate{X: x, Y: y, Z: z})
+					}
+				}
+			}
+
+			// Dry-run preview: an ASCII overlay of the box before anything changes.
+			width := endX - startX + 1
+			height := endY - startY + 1
+			depth := endZ - startZ + 1
+
+			preview := newMapBuilder(width, height, depth)
+			preview.setUserRoom(session.room)
+			for _, loc := range selection {
+				preview.markSelected((loc.X-startX)*2, (loc.Y-startY)*2, loc.Z-startZ)
+				if room := model.M.GetRoomByLocation(loc, session.zone); room != nil {
+					preview.addRoom(room, (loc.X-startX)*2, (loc.Y-startY)*2, loc.Z-startZ)
+				}
+			}
+			session.printLine(utils.TrimEmptyRows(preview.toString(session.user.GetColorMode())))
+
+			menu := utils.NewMenu("Batch Edit")
+			menu.AddAction("d", "[D]escription template")
+			menu.AddAction("t", "[T]oggle perimeter exits")
+			menu.AddAction("c", "[C]reate missing rooms")
+			menu.AddAction("x", "[X] Delete all rooms in selection")
+
+			choice, _ := execMenu(menu)
+
+			switch choice {
+			case "d":
+				template := getUserInput(RawUserInput, "Description template: ")
+				if template == "" {
+					return
+				}
+				for _, loc := range selection {
+					if room := model.M.GetRoomByLocation(loc, session.zone); room != nil {
+						room.SetDescription(template)
+					}
+				}
+				session.printLine("Description applied")
+
+			case "t":
+				for _, loc := range selection {
+					room := model.M.GetRoomByLocation(loc, session.zone)
+					if room == nil {
+						continue
+					}
+
+					for _, dir := range database.AllDirections() {
+						next := room.NextLocation(dir)
+						if next.X < startX || next.X > endX || next.Y < startY || next.Y > endY || next.Z < startZ || next.Z > endZ {
+							room.SetExitEnabled(dir, !room.HasExit(dir))
+						}
+					}
+				}
+				session.printLine("Perimeter exits toggled")
+
+			case "c":
+				created := 0
+				for _, loc := range selection {
+					if model.M.GetRoomByLocation(loc, session.zone) == nil {
+						model.M.CreateRoomAt(session.zone, loc)
+						created++
+					}
+				}
+				session.printLine("Created %d rooms", created)
+
+			case "x":
+				deleted := 0
+				for _, loc := range selection {
+					if room := model.M.GetRoomByLocation(loc, session.zone); room != nil {
+						model.DeleteRoom(room)
+						deleted++
+					}
+				}
+				session.printLine("Deleted %d rooms", deleted)
+			}
+
 		case "zone":
 			if len(args) == 0 {
 				if session.zone.GetId() == "" {
@@ -444,7 +871,7 @@ func (session *Session) Exec() {
 			fallthrough
 		case "tel":
 			telUsage := func() {
-				session.printError("Usage: /teleport [<zone>|<X> <Y> <Z>]")
+				session.printError("Usage: /teleport [<zone>|<X> <Y> <Z>] (no arguments: the crosshair, while aiming)")
 			}
 
 			x := 0
@@ -453,7 +880,11 @@ func (session *Session) Exec() {
 
 			newZone := session.zone
 
-			if len(args) == 1 {
+			if len(args) == 0 && session.xhair != nil {
+				x = session.xhair.X
+				y = session.xhair.Y
+				z = session.xhair.Z
+			} else if len(args) == 1 {
 				newZone = model.M.GetZoneByName(args[0])
 
 				if newZone == nil {
@@ -573,23 +1004,68 @@ func (session *Session) Exec() {
 			}
 
 		case "destroyroom":
-			if len(args) == 1 {
+			var loc database.Coordinate
+
+			if len(args) == 0 && session.xhair != nil {
+				loc = *session.xhair
+			} else if len(args) == 1 {
 				direction := database.StringToDirection(args[0])
 
 				if direction == database.DirectionNone {
 					session.printError("Not a valid direction")
-				} else {
-					loc := session.room.NextLocation(direction)
-					roomToDelete := model.M.GetRoomByLocation(loc, session.zone)
-					if roomToDelete != nil {
-						model.DeleteRoom(roomToDelete)
-						session.printLine("Room destroyed")
-					} else {
-						session.printError("No room in that direction")
-					}
+					return
 				}
+
+				loc = session.room.NextLocation(direction)
 			} else {
 				session.printError("Usage: /destroyroom <direction>")
+				return
+			}
+
+			roomToDelete := model.M.GetRoomByLocation(loc, session.zone)
+			if roomToDelete != nil {
+				model.DeleteRoom(roomToDelete)
+				session.printLine("Room destroyed")
+			} else {
+				session.printError("No room there")
+			}
+
+		case "aim":
+			aimUsage := func() {
+				session.printError("Usage: /aim [<direction>|clear|accept]")
+			}
+
+			if session.xhair == nil {
+				loc := session.room.GetLocation()
+				session.xhair = &loc
+			}
+
+			if len(args) == 0 {
+				session.printLine("Aiming at %v", *session.xhair)
+				return
+			}
+
+			switch args[0] {
+			case "clear":
+				session.xhair = nil
+				session.printLine("Aim cleared")
+
+			case "accept":
+				session.printLine("Crosshair locked at %v", *session.xhair)
+
+			default:
+				direction := database.StringToDirection(args[0])
+				if direction == database.DirectionNone {
+					aimUsage()
+					return
+				}
+
+				delta := directionDelta(direction)
+				session.xhair.X += delta.X
+				session.xhair.Y += delta.Y
+				session.xhair.Z += delta.Z
+
+				session.printLine("Aiming at %v", *session.xhair)
 			}
 
 		case "npc":
@@ -639,19 +1115,72 @@ func (session *Session) Exec() {
 					}
 					npc := model.M.GetCharacter(npcId)
 					npc.SetName(name)
-				case "c":
+				case "t":
 					npc := model.M.GetCharacter(npcId)
-					conversation := npc.GetConversation()
-
-					if conversation == "" {
-						conversation = "<empty>"
+					tree := npc.GetDialogueTree()
+					if tree == nil {
+						tree = database.NewDialogueTree()
+						npc.SetDialogueTree(tree)
 					}
 
-					session.printLine("Conversation: %s", conversation)
-					newConversation := getUserInput(RawUserInput, "New conversation text: ")
+					for {
+						treeChoice, _ := execMenu(dialogueEditorMenu(tree))
+
+						if treeChoice == "" {
+							break
+						}
+
+						switch treeChoice {
+						case "a":
+							id := getUserInput(CleanUserInput, "New node id: ")
+							if id == "" {
+								break
+							}
+							node := tree.AddNode(id)
+							node.NpcLine = getUserInput(RawUserInput, "NPC line: ")
+							npc.SetDialogueTree(tree)
+
+						case "l":
+							fromId := getUserInput(CleanUserInput, "From node id: ")
+							from, ok := tree.Nodes[fromId]
+							if !ok {
+								session.printError("No such node: %s", fromId)
+								break
+							}
+
+							choiceText := getUserInput(RawUserInput, "Choice text shown to the player: ")
+							nextId := getUserInput(CleanUserInput, "Node id this choice leads to: ")
+
+							from.Choices = append(from.Choices, database.DialogueChoice{Text: choiceText, NextId: nextId})
+							npc.SetDialogueTree(tree)
 
-					if newConversation != "" {
-						npc.SetConversation(newConversation)
+						case "del":
+							id := getUserInput(CleanUserInput, "Node id to delete: ")
+							if id == tree.RootId {
+								session.printError("Can't delete the root node")
+								break
+							}
+							tree.DeleteNode(id)
+							npc.SetDialogueTree(tree)
+
+						case "root":
+							id := getUserInput(CleanUserInput, "New root node id: ")
+							if _, ok := tree.Nodes[id]; !ok {
+								session.printError("No such node: %s", id)
+								break
+							}
+							tree.RootId = id
+							npc.SetDialogueTree(tree)
+
+						case "test":
+							walkDialogueTree(tree, true)
+
+						default:
+							// A node id was selected directly; jump the test-run there.
+							if _, ok := tree.Nodes[treeChoice]; ok {
+								walkDialogueTreeFrom(tree, treeChoice, true)
+							}
+						}
 					}
 				}
 			}
@@ -725,6 +1254,157 @@ func (session *Session) Exec() {
 		case "roomid":
 			session.printLine("Room ID: %v", session.room.GetId())
 
+		case "record":
+			if recorder.active {
+				session.printError("Already recording macro '%s'", recorder.name)
+				return
+			}
+
+			if len(args) != 1 {
+				session.printError("Usage: /record <name>")
+				return
+			}
+
+			recorder.active = true
+			recorder.name = args[0]
+			recorder.lines = nil
+			session.printLine("Recording macro '%s'. Use /endrecord to finish.", recorder.name)
+
+		case "endrecord":
+			if !recorder.active {
+				session.printError("Not currently recording")
+				return
+			}
+
+			session.user.SetMacro(recorder.name, recorder.lines)
+			session.printLine("Saved macro '%s' (%d commands)", recorder.name, len(recorder.lines))
+			recorder.active = false
+
+		case "macros":
+			macros := session.user.GetMacros()
+
+			session.printLine("")
+			session.printLine("Macros")
+			session.printLine("------")
+			for name := range macros {
+				session.printLine(name)
+			}
+			session.printLine("")
+
+		case "play":
+			if len(args) < 1 || len(args) > 2 {
+				session.printError("Usage: /play <name> [count]")
+				return
+			}
+
+			lines, ok := session.user.GetMacro(args[0])
+			if !ok {
+				session.printError("No macro named '%s'", args[0])
+				return
+			}
+
+			count := 1
+			if len(args) == 2 {
+				var err error
+				count, err = strconv.Atoi(args[1])
+				if err != nil || count < 1 {
+					session.printError("Usage: /play <name> [count]")
+					return
+				}
+			}
+
+			playMacro(lines, count)
+
+		case "alias":
+			aliasUsage := func() {
+				session.printError("Usage: /alias <name> <expansion> | /alias list | /alias del <name>")
+			}
+
+			if len(args) == 0 {
+				aliasUsage()
+				return
+			}
+
+			switch args[0] {
+			case "list":
+				aliases := session.user.GetAliases()
+
+				session.printLine("")
+				session.printLine("Aliases")
+				session.printLine("-------")
+				for name, body := range aliases {
+					session.printLine("%s: %s", name, body)
+				}
+				session.printLine("")
+
+			case "del":
+				if len(args) != 2 {
+					aliasUsage()
+					return
+				}
+
+				session.user.DeleteAlias(args[1])
+				session.printLine("Deleted alias '%s'", args[1])
+
+			default:
+				if len(args) < 2 {
+					aliasUsage()
+					return
+				}
+
+				name := args[0]
+				body := strings.Join(args[1:], " ")
+
+				session.user.SetAlias(name, body)
+				session.printLine("Alias '%s' set to: %s", name, body)
+			}
+
+		case "exportarea":
+			if len(args) != 1 {
+				session.printError("Usage: /exportarea <name>")
+				return
+			}
+
+			area := model.M.GetAreaByName(args[0])
+			if area == nil {
+				session.printError("Area '%s' not found", args[0])
+				return
+			}
+
+			data, err := areaio.Export(area)
+			if err != nil {
+				session.printError(err.Error())
+				return
+			}
+
+			filename := area.GetName() + ".json"
+			if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+				session.printError(err.Error())
+				return
+			}
+
+			session.printLine("Exported area to %s", filename)
+
+		case "importarea":
+			if len(args) != 1 {
+				session.printError("Usage: /importarea <file>")
+				return
+			}
+
+			data, err := ioutil.ReadFile(args[0])
+			if err != nil {
+				session.printError(err.Error())
+				return
+			}
+
+			area, err := areaio.Import(data)
+			if err != nil {
+				session.printError(err.Error())
+				return
+			}
+
+			session.printLine("Imported area: %s", area.GetName())
+
 		default:
 			session.printError("Unrecognized command: %s", command)
 		}
@@ -735,7 +1415,7 @@ func (session *Session) Exec() {
 
 	// Main routine in charge of actually reading input from the connection object,
 	// also has built in throttling to limit how fast we are allowed to process
-	// commands from the user. 
+	// commands from the user.
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
@@ -752,6 +1432,8 @@ func (session *Session) Exec() {
 			prompt := utils.Colorize(session.user.GetColorMode(), utils.ColorWhite, <-promptChannel)
 			input := ""
 
+			stopTimer := session.inputTimer.Time()
+
 			switch mode {
 			case CleanUserInput:
 				input = utils.GetUserInput(session.conn, prompt)
@@ -761,6 +1443,8 @@ func (session *Session) Exec() {
 				panic("Unhandled case in switch statement (userInputMode)")
 			}
 
+			stopTimer()
+
 			diff := time.Since(lastTime)
 
 			if diff < delay {
@@ -774,24 +1458,29 @@ func (session *Session) Exec() {
 
 	// Main loop
 	for {
-		input := getUserInput(RawUserInput, prompt())
+		input := getUserInput(RawUserInput, session.prompt())
 		if input == "" || input == "logout" {
 			return
 		}
-		if strings.HasPrefix(input, "/") {
-			processCommand(utils.Argify(input[1:]))
-		} else {
-			session.handleAction(utils.Argify(input))
-		}
+		executeLine(input, 0)
 	}
 }
 
 func (session *Session) printString(data string) {
+	session.outputMu.Lock()
+	defer session.outputMu.Unlock()
+
 	io.WriteString(session.conn, data)
 }
 
 func (session *Session) printLineColor(color utils.Color, line string, a ...interface{}) {
-	utils.WriteLine(session.conn, utils.Colorize(session.user.GetColorMode(), color, fmt.Sprintf(line, a...)))
+	rendered := utils.Colorize(session.user.GetColorMode(), color, fmt.Sprintf(line, a...))
+	session.writeBytesGauge.Set(float64(len(rendered)))
+
+	session.outputMu.Lock()
+	defer session.outputMu.Unlock()
+
+	utils.WriteLine(session.conn, rendered)
 }
 
 func (session *Session) printLine(line string, a ...interface{}) {
@@ -803,10 +1492,17 @@ func (session *Session) printError(err string, a ...interface{}) {
 }
 
 func (session *Session) printRoom() {
-	playerList := model.M.PlayersIn(session.room, session.player)
-	npcList := model.M.NpcsIn(session.room)
-	session.printLine(session.room.ToString(database.ReadMode, session.user.GetColorMode(),
-		playerList, npcList, model.M.GetItems(session.room.GetItemIds())))
+	session.printRoomAt(session.room)
+}
+
+// printRoomAt prints room as if it were the player's current room, without
+// moving them there - used by look's crosshair fallback to preview a room
+// at the aimed-at location.
+func (session *Session) printRoomAt(room *database.Room) {
+	playerList := model.M.PlayersIn(room, session.player)
+	npcList := model.M.NpcsIn(room)
+	session.printLine(room.ToString(database.ReadMode, session.user.GetColorMode(),
+		playerList, npcList, model.M.GetItems(room.GetItemIds())))
 }
 
 func (session *Session) printRoomEditor() {
@@ -814,10 +1510,16 @@ func (session *Session) printRoomEditor() {
 }
 
 func (session *Session) clearLine() {
+	session.outputMu.Lock()
+	defer session.outputMu.Unlock()
+
 	utils.ClearLine(session.conn)
 }
 
-func prompt() string {
+func (session *Session) prompt() string {
+	if session.xhair != nil {
+		return fmt.Sprintf("[aim %v,%v,%v] > ", session.xhair.X, session.xhair.Y, session.xhair.Z)
+	}
 	return "> "
 }
 
@@ -0,0 +1,52 @@
+// Package storage wires a concrete database.Backend in based on config,
+// keeping the database package itself free of knowledge about any one
+// implementation (which is what lets fsdb/leveldbstore import it without an
+// import cycle).
+package storage
+
+import (
+	"fmt"
+	"kmud/database"
+	"kmud/database/fsdb"
+	"kmud/database/leveldbstore"
+	"kmud/database/mongodb"
+)
+
+// Config selects and configures a backend. Exactly one of the
+// implementation-specific fields is read, based on Kind.
+type Config struct {
+	Kind string // "mongo", "fsdb", or "leveldb"
+
+	// Mongo
+	MongoURL string
+	MongoDB  string
+
+	// FSDB / LevelDB
+	Path string
+}
+
+// New builds the Backend described by cfg and installs it via
+// database.SetBackend, so model code can start calling NewArea, SetName,
+// etc. immediately.
+func New(cfg Config) (database.Backend, error) {
+	var backend database.Backend
+	var err error
+
+	switch cfg.Kind {
+	case "mongo":
+		backend, err = mongodb.New(cfg.MongoURL, cfg.MongoDB)
+	case "fsdb":
+		backend, err = fsdb.New(cfg.Path)
+	case "leveldb":
+		backend, err = leveldbstore.New(cfg.Path)
+	default:
+		return nil, fmt.Errorf("storage: unknown backend kind %q", cfg.Kind)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	database.SetBackend(backend)
+	return backend, nil
+}
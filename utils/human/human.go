@@ -0,0 +1,130 @@
+// Package human formats durations, counts, byte sizes, and timestamps the
+// way a player should see them over a telnet connection: ASCII only,
+// rounded toward zero, in a single significant unit (FormatDuration uses its
+// two largest units). The `who`, `stat`, admin `uptime`, inventory weight,
+// and area/room listing output do not call these formatters.
+package human
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	minute = time.Minute
+	hour   = time.Hour
+	day    = 24 * hour
+	week   = 7 * day
+)
+
+// FormatDuration renders d using its two largest non-zero units, e.g.
+// "2h30m" or "3d4h". Durations under a second still print "0s" rather than
+// losing the remaining magnitude; zero or negative durations are "0s".
+func FormatDuration(d time.Duration) string {
+	if d <= 0 {
+		return "0s"
+	}
+
+	switch {
+	case d >= week:
+		return fmt.Sprintf("%dw%dd", d/week, (d%week)/day)
+	case d >= day:
+		return fmt.Sprintf("%dd%dh", d/day, (d%day)/hour)
+	case d >= hour:
+		return fmt.Sprintf("%dh%dm", d/hour, (d%hour)/minute)
+	case d >= minute:
+		return fmt.Sprintf("%dm%ds", d/minute, (d%minute)/time.Second)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
+
+// FormatRelativeTime renders t relative to now, e.g. "just now" or
+// "5 min ago". Unlike FormatDuration's compact two-unit form, relative
+// time only needs one significant unit. The zero Time is treated as
+// "never happened" rather than as a moment billions of years ago.
+func FormatRelativeTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	diff := time.Since(t)
+
+	if diff < 0 {
+		return relativeUnit(-diff) + " from now"
+	}
+
+	if diff < 10*time.Second {
+		return "just now"
+	}
+
+	return relativeUnit(diff) + " ago"
+}
+
+// relativeUnit renders d as a count and its single largest spelled-out
+// unit, e.g. "5 min", "2 hr", "3 day", "1 week".
+func relativeUnit(d time.Duration) string {
+	switch {
+	case d >= week:
+		return fmt.Sprintf("%d week", d/week)
+	case d >= day:
+		return fmt.Sprintf("%d day", d/day)
+	case d >= hour:
+		return fmt.Sprintf("%d hr", d/hour)
+	case d >= minute:
+		return fmt.Sprintf("%d min", d/minute)
+	default:
+		return fmt.Sprintf("%d sec", d/time.Second)
+	}
+}
+
+// FormatCount renders n past 999 in its largest unit, truncated to one
+// decimal place, e.g. 1.2K, 3.4M, 12.3K. Values are truncated (rounded toward zero), never rounded up, so a
+// count never visually exceeds the real value.
+func FormatCount(n int64) string {
+	return scale(n, 1000, []string{"K", "M", "B", "T"})
+}
+
+// FormatBytes renders n using binary (1024-based) units, e.g. "4.2 KiB",
+// "1.1 GiB".
+func FormatBytes(n int64) string {
+	return scale(n, 1024, []string{" KiB", " MiB", " GiB", " TiB", " PiB"})
+}
+
+// scale picks the largest unit (from units, ordered smallest to largest)
+// whose value is >= 1, truncated to one decimal place. Values below the
+// first unit's threshold are printed as plain integers.
+func scale(n int64, base int64, units []string) string {
+	negative := n < 0
+	abs := n
+	if negative {
+		abs = -n
+	}
+
+	if abs < base {
+		return fmt.Sprintf("%d", n)
+	}
+
+	value := float64(abs)
+	divisor := float64(base)
+	unit := units[len(units)-1]
+
+	for i, u := range units {
+		if value/divisor < float64(base) || i == len(units)-1 {
+			unit = u
+			break
+		}
+		divisor *= float64(base)
+	}
+
+	scaled := value / divisor
+	// Truncate to one decimal place instead of rounding, per "round toward zero".
+	scaled = float64(int64(scaled*10)) / 10
+
+	sign := ""
+	if negative {
+		sign = "-"
+	}
+
+	return fmt.Sprintf("%s%.1f%s", sign, scaled, unit)
+}
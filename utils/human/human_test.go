@@ -0,0 +1,88 @@
+package human
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_FormatDuration(t *testing.T) {
+	var tests = []struct {
+		input time.Duration
+		want  string
+	}{
+		{0, "0s"},
+		{-5 * time.Second, "0s"},
+		{30 * time.Second, "30s"},
+		{90 * time.Second, "1m30s"},
+		{150 * time.Minute, "2h30m"},
+		{76 * time.Hour, "3d4h"},
+		{9 * day, "1w2d"},
+	}
+
+	for _, c := range tests {
+		got := FormatDuration(c.input)
+		if got != c.want {
+			t.Errorf("FormatDuration(%v) == %q, want %q", c.input, got, c.want)
+		}
+	}
+}
+
+func Test_FormatRelativeTime(t *testing.T) {
+	var tests = []struct {
+		input time.Time
+		want  string
+	}{
+		{time.Time{}, "never"},
+		{time.Now(), "just now"},
+		{time.Now().Add(-5 * time.Minute), "5 min ago"},
+	}
+
+	for _, c := range tests {
+		got := FormatRelativeTime(c.input)
+		if got != c.want {
+			t.Errorf("FormatRelativeTime(%v) == %q, want %q", c.input, got, c.want)
+		}
+	}
+}
+
+func Test_FormatCount(t *testing.T) {
+	var tests = []struct {
+		input int64
+		want  string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1000, "1.0K"},
+		{1999, "1.9K"},
+		{1234567, "1.2M"},
+		{-1500, "-1.5K"},
+	}
+
+	for _, c := range tests {
+		got := FormatCount(c.input)
+		if got != c.want {
+			t.Errorf("FormatCount(%v) == %q, want %q", c.input, got, c.want)
+		}
+	}
+}
+
+func Test_FormatBytes(t *testing.T) {
+	var tests = []struct {
+		input int64
+		want  string
+	}{
+		{0, "0"},
+		{1023, "1023"},
+		{4301, "4.2 KiB"},
+		{1181116007, "1.1 GiB"},
+	}
+
+	for _, c := range tests {
+		got := FormatBytes(c.input)
+		if got != c.want {
+			t.Errorf("FormatBytes(%v) == %q, want %q", c.input, got, c.want)
+		}
+	}
+}
+
+// vim:nocindent
@@ -0,0 +1,274 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNoMatch is returned by BestMatchScored when input doesn't match any
+// candidate in the search list, on any tier.
+var ErrNoMatch = errors.New("no match")
+
+// ErrAmbiguous is the sentinel to compare against with errors.Is when
+// BestMatchScored returns an *AmbiguousMatchError; use errors.As on the same
+// error to recover which candidates tied.
+var ErrAmbiguous = errors.New("ambiguous match")
+
+// AmbiguousMatchError is returned by BestMatchScored when two or more
+// candidates tie for the best match. Indices lists every tied candidate so
+// callers can present a disambiguation prompt.
+type AmbiguousMatchError struct {
+	Indices []int
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	return ErrAmbiguous.Error()
+}
+
+func (e *AmbiguousMatchError) Unwrap() error {
+	return ErrAmbiguous
+}
+
+// matchTier ranks how a candidate matched, best first. Within a tier,
+// BestMatchScored breaks ties by shorter candidate length, then by earlier
+// match position.
+type matchTier int
+
+const (
+	tierExact matchTier = iota
+	tierPrefix
+	tierWordBoundaryPrefix
+	tierSubstring
+	tierSubsequence
+	tierNone
+)
+
+// score is an internal bookkeeping struct for one candidate's best-known
+// match quality.
+type score struct {
+	index    int
+	tier     matchTier
+	position int
+	length   int
+}
+
+// less reports whether s is a strictly better match than other: a lower
+// (better) tier wins; within a tier, the shorter candidate wins; if still
+// tied, the earlier match position wins.
+func (s score) less(other score) bool {
+	if s.tier != other.tier {
+		return s.tier < other.tier
+	}
+	if s.length != other.length {
+		return s.length < other.length
+	}
+	return s.position < other.position
+}
+
+// equal reports whether s and other are an exact tie (same tier, length,
+// and position) - i.e. genuinely ambiguous, not just close.
+func (s score) equal(other score) bool {
+	return s.tier == other.tier && s.length == other.length && s.position == other.position
+}
+
+// initials returns the first rune of every whitespace-separated word in
+// candidate, concatenated, e.g. "Red Sword" -> "rs". This is what lets "rs"
+// match "Red Sword": an acronym made from the start of each word.
+func initials(candidate string) string {
+	var sb strings.Builder
+	for _, word := range strings.Fields(candidate) {
+		for _, r := range word {
+			sb.WriteRune(r)
+			break
+		}
+	}
+	return sb.String()
+}
+
+// isWordBoundaryPrefix reports whether input is a prefix of candidate's
+// initials, e.g. "rs" against "Red Sword" (initials "rs").
+func isWordBoundaryPrefix(input, candidate string) bool {
+	return len(strings.Fields(candidate)) > 1 && strings.HasPrefix(initials(candidate), input)
+}
+
+// subsequenceIndex reports whether every rune of input appears, in order,
+// somewhere in candidate (not necessarily contiguous), and if so the index
+// of the first matched rune.
+func subsequenceIndex(input, candidate string) (int, bool) {
+	inputRunes := []rune(input)
+	candidateRunes := []rune(candidate)
+
+	pos := 0
+	start := -1
+
+	for i, r := range candidateRunes {
+		if pos < len(inputRunes) && r == inputRunes[pos] {
+			if pos == 0 {
+				start = i
+			}
+			pos++
+		}
+	}
+
+	return start, pos == len(inputRunes)
+}
+
+// classify scores how well input matches candidate, assuming both have
+// already been folded to a comparable case.
+func classify(input, candidate string) (matchTier, int) {
+	if input == "" {
+		return tierNone, 0
+	}
+
+	if input == candidate {
+		return tierExact, 0
+	}
+
+	if strings.HasPrefix(candidate, input) {
+		return tierPrefix, 0
+	}
+
+	if isWordBoundaryPrefix(input, candidate) {
+		return tierWordBoundaryPrefix, 0
+	}
+
+	if idx := strings.Index(candidate, input); idx >= 0 {
+		return tierSubstring, idx
+	}
+
+	if idx, ok := subsequenceIndex(input, candidate); ok {
+		return tierSubsequence, idx
+	}
+
+	return tierNone, 0
+}
+
+// BestMatchScored finds the best match for input among searchList, using
+// tiered fuzzy matching: exact case-insensitive match beats a prefix match,
+// which beats a word-boundary prefix match (e.g. "rs" matches "Red Sword"),
+// which beats a contiguous substring match, which beats a subsequence match
+// (e.g. "rsw" matches "Rusty Short-sword"). Ties within a tier are broken by
+// shorter candidate length, then by earlier match position.
+//
+// It returns the winning index and its score, or ErrNoMatch if nothing
+// matched on any tier, or an *AmbiguousMatchError if two or more candidates
+// tied exactly.
+func BestMatchScored(input string, searchList []string) (index int, matchScore int, err error) {
+	foldedInput := strings.ToLower(input)
+
+	var best *score
+	var tied []int
+
+	for i, candidate := range searchList {
+		if candidate == "" {
+			continue
+		}
+
+		tier, position := classify(foldedInput, strings.ToLower(candidate))
+		if tier == tierNone {
+			continue
+		}
+
+		s := score{index: i, tier: tier, position: position, length: utf8.RuneCountInString(candidate)}
+
+		switch {
+		case best == nil || s.less(*best):
+			best = &s
+			tied = []int{i}
+		case s.equal(*best):
+			tied = append(tied, i)
+		}
+	}
+
+	if best == nil {
+		return -1, 0, ErrNoMatch
+	}
+
+	if len(tied) > 1 {
+		return -1, 0, &AmbiguousMatchError{Indices: tied}
+	}
+
+	return best.index, int(tierNone - best.tier), nil
+}
+
+// BestMatch is the legacy entry point, preserved for existing call sites:
+// it returns the matched index, -1 if nothing matched, or -2 if the match
+// was ambiguous. New code should prefer BestMatchScored, which reports
+// which candidates tied and why.
+//
+// Ambiguity here is judged the way callers of this function have always
+// relied on it being judged: by tier and match position alone, without
+// BestMatchScored's shorter-candidate tiebreak. "ab" against both
+// "Abcdef" and "Abc" is ambiguous to BestMatch even though BestMatchScored
+// picks "Abc" - existing callers like look/get/attack expect a disambiguation
+// prompt there, not a silent guess.
+//
+// BestMatch also only ever honors the exact and prefix tiers, the only two
+// the matcher it replaces ever understood. A win at the word-boundary,
+// substring, or subsequence tier - "ord" matching "Sword", say - is reported
+// as no match, not a surprising new one.
+func BestMatch(input string, searchList []string) int {
+	index, _, err := BestMatchScored(input, searchList)
+
+	switch {
+	case err == nil:
+		tier, _ := classify(strings.ToLower(input), strings.ToLower(searchList[index]))
+		if tier != tierExact && tier != tierPrefix {
+			return -1
+		}
+		if tierPositionTied(input, searchList) {
+			return -2
+		}
+		return index
+	case errors.Is(err, ErrAmbiguous):
+		var ambiguous *AmbiguousMatchError
+		if errors.As(err, &ambiguous) {
+			tier, _ := classify(strings.ToLower(input), strings.ToLower(searchList[ambiguous.Indices[0]]))
+			if tier != tierExact && tier != tierPrefix {
+				return -1
+			}
+		}
+		return -2
+	default:
+		return -1
+	}
+}
+
+// tierPositionTied reports whether two or more candidates share the best
+// (tier, position) pair, ignoring length - BestMatch's notion of
+// ambiguity, coarser than BestMatchScored's.
+func tierPositionTied(input string, searchList []string) bool {
+	foldedInput := strings.ToLower(input)
+
+	type coarse struct {
+		tier     matchTier
+		position int
+	}
+
+	var best *coarse
+	tied := 0
+
+	for _, candidate := range searchList {
+		if candidate == "" {
+			continue
+		}
+
+		tier, position := classify(foldedInput, strings.ToLower(candidate))
+		if tier == tierNone {
+			continue
+		}
+
+		c := coarse{tier, position}
+
+		switch {
+		case best == nil || c.tier < best.tier || (c.tier == best.tier && c.position < best.position):
+			best = &c
+			tied = 1
+		case c.tier == best.tier && c.position == best.position:
+			tied++
+		}
+	}
+
+	return tied > 1
+}
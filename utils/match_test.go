@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_BestMatchScored(t *testing.T) {
+	var tests = []struct {
+		name       string
+		searchList []string
+		input      string
+		wantIndex  int
+		wantErr    error
+	}{
+		{
+			name:       "empty input matches nothing",
+			searchList: []string{"Foo", "Bar"},
+			input:      "",
+			wantIndex:  -1,
+			wantErr:    ErrNoMatch,
+		},
+		{
+			name:       "empty search list",
+			searchList: []string{},
+			input:      "foo",
+			wantIndex:  -1,
+			wantErr:    ErrNoMatch,
+		},
+		{
+			name:       "single char prefix",
+			searchList: []string{"Foo", "Bar"},
+			input:      "f",
+			wantIndex:  0,
+		},
+		{
+			name:       "exact match wins over prefix match",
+			searchList: []string{"Ab", "Abcdef"},
+			input:      "ab",
+			wantIndex:  0,
+		},
+		{
+			name:       "prefix tie resolves to shorter candidate",
+			searchList: []string{"Abcdef", "Abc"},
+			input:      "ab",
+			wantIndex:  1,
+		},
+		{
+			name:       "same-length prefix tie is ambiguous",
+			searchList: []string{"Bar", "Bob"},
+			input:      "b",
+			wantErr:    ErrAmbiguous,
+		},
+		{
+			name:       "word-boundary acronym match",
+			searchList: []string{"Health Potion", "Red Sword"},
+			input:      "rs",
+			wantIndex:  1,
+		},
+		{
+			name:       "word-boundary match beats substring",
+			searchList: []string{"Mars", "Red Sword"},
+			input:      "rs",
+			wantIndex:  1,
+		},
+		{
+			name:       "contiguous substring match picks earlier position",
+			searchList: []string{"Barfoo", "Foobar"},
+			input:      "oo",
+			wantIndex:  1,
+		},
+		{
+			name:       "substring tie at same position and length is ambiguous",
+			searchList: []string{"Foobar", "Moobar"},
+			input:      "oo",
+			wantErr:    ErrAmbiguous,
+		},
+		{
+			name:       "subsequence match",
+			searchList: []string{"Health Potion", "Rusty Short-sword"},
+			input:      "rsw",
+			wantIndex:  1,
+		},
+		{
+			name:       "no subsequence match",
+			searchList: []string{"Rusty Short-sword"},
+			input:      "xyz",
+			wantErr:    ErrNoMatch,
+		},
+		{
+			name:       "unicode candidates",
+			searchList: []string{"Héllo", "Hello"},
+			input:      "héllo",
+			wantIndex:  0,
+		},
+		{
+			name:       "unicode subsequence",
+			searchList: []string{"火の剣", "氷の盾"},
+			input:      "火剣",
+			wantIndex:  0,
+		},
+		{
+			name:       "full tie across every field",
+			searchList: []string{"Foo", "Foo"},
+			input:      "foo",
+			wantErr:    ErrAmbiguous,
+		},
+		{
+			name:       "skips blank candidates",
+			searchList: []string{"", "Foo"},
+			input:      "foo",
+			wantIndex:  1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			index, _, err := BestMatchScored(test.input, test.searchList)
+
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("BestMatchScored(%q) error == %v, want %v", test.input, err, test.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Errorf("BestMatchScored(%q) unexpected error: %v", test.input, err)
+				return
+			}
+
+			if index != test.wantIndex {
+				t.Errorf("BestMatchScored(%q) == %v, want %v", test.input, index, test.wantIndex)
+			}
+		})
+	}
+}
+
+func Test_AmbiguousMatchErrorIndices(t *testing.T) {
+	_, _, err := BestMatchScored("b", []string{"Bar", "Bob"})
+
+	var ambiguous *AmbiguousMatchError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("expected *AmbiguousMatchError, got %T: %v", err, err)
+	}
+
+	if len(ambiguous.Indices) != 2 {
+		t.Errorf("Indices == %v, want two tied indices", ambiguous.Indices)
+	}
+}
+
+// vim:nocindent
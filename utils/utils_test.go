@@ -166,7 +166,7 @@ func Test_ValidateName(t *testing.T) {
 }
 
 func Test_BestMatch(t *testing.T) {
-	searchList := []string{"", "Foo", "Bar", "Joe", "Bob", "Abcdef", "Abc", "QrStUv"}
+	searchList := []string{"", "Foo", "Bar", "Joe", "Bob", "Abcdef", "Abc", "QrStUv", "Sword"}
 
 	var tests = []struct {
 		input  string
@@ -175,11 +175,12 @@ func Test_BestMatch(t *testing.T) {
 		{"f", 1},
 		{"B", -2},
 		{"alseifjlfji", -1},
-		{"AB", -2},
+		{"AB", -2}, // "Abcdef" and "Abc" tie at the same tier/position; BestMatch's legacy semantics treat that as ambiguous
 		{"aBc", 6},
 		{"AbCd", 5},
 		{"q", 7},
 		{"jo", 3},
+		{"ord", -1}, // substring-tier match only; the legacy matcher never looked past prefix
 	}
 
 	for _, test := range tests {